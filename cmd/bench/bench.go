@@ -0,0 +1,128 @@
+// Package bench implements the "fritzctl bench" subcommand, which load-tests the authenticated
+// AHA HTTP endpoints of a FRITZ!Box and reports latency, throughput and error statistics.
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	fritzbench "github.com/bpicode/fritzctl/fritz/bench"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagConfigFile  string
+	flagScenario    string
+	flagAid         string
+	flagRawTemp     string
+	flagConcurrency int
+	flagDuration    time.Duration
+	flagRPS         float64
+	flagJSON        bool
+)
+
+// Command is the "bench" subcommand.
+var Command = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark the AHA HTTP interface of a FRITZ!Box",
+	Long: "bench drives the authenticated AHA endpoints (device list, thermostat writes, switch " +
+		"toggles, or a mixed read/write workload) under configurable concurrency, so users can " +
+		"characterize what their box actually tolerates before it starts throwing 500s.",
+	RunE: runBench,
+}
+
+func init() {
+	flags := Command.Flags()
+	flags.StringVar(&flagConfigFile, "config", "", "path to the fritzctl configuration file")
+	flags.StringVar(&flagScenario, "scenario", "devicelist", "workload to run: devicelist, thermostat, switch, mixed")
+	flags.StringVar(&flagAid, "aid", "", "AIN of the device driven by the thermostat/switch/mixed scenarios")
+	flags.StringVar(&flagRawTemp, "temp", "42", "raw AHA target temperature used by the thermostat/mixed scenarios")
+	flags.IntVar(&flagConcurrency, "concurrency", 4, "number of concurrent workers")
+	flags.DurationVar(&flagDuration, "duration", 30*time.Second, "how long to run the benchmark for")
+	flags.Float64Var(&flagRPS, "rps", 0, "cap on requests per second across all workers, 0 = uncapped")
+	flags.BoolVar(&flagJSON, "json", false, "emit machine-readable JSON instead of a table")
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	scenario, err := scenarioFor(flagScenario)
+	if err != nil {
+		return err
+	}
+	if flagRPS < 0 {
+		return fmt.Errorf("--rps must not be negative, got %v", flagRPS)
+	}
+
+	client, err := fritz.NewClient(flagConfigFile)
+	if err != nil {
+		return fmt.Errorf("unable to create client: %w", err)
+	}
+
+	ctx := cmd.Context()
+	if err := client.LoginCtx(ctx); err != nil {
+		return fmt.Errorf("unable to login: %w", err)
+	}
+
+	result, err := fritzbench.Run(ctx, client, scenario, fritzbench.Config{
+		Concurrency: flagConcurrency,
+		Duration:    flagDuration,
+		RPS:         flagRPS,
+	})
+	if err != nil {
+		return fmt.Errorf("benchmark failed: %w", err)
+	}
+
+	if flagJSON {
+		return printJSON(result)
+	}
+	return printTable(result)
+}
+
+func scenarioFor(name string) (fritzbench.Scenario, error) {
+	switch name {
+	case "devicelist":
+		return fritzbench.DeviceList(), nil
+	case "thermostat":
+		if flagAid == "" {
+			return nil, fmt.Errorf("--aid is required for the thermostat scenario")
+		}
+		return fritzbench.ThermostatWrite(flagAid, flagRawTemp), nil
+	case "switch":
+		if flagAid == "" {
+			return nil, fmt.Errorf("--aid is required for the switch scenario")
+		}
+		return fritzbench.SwitchToggle(flagAid), nil
+	case "mixed":
+		if flagAid == "" {
+			return nil, fmt.Errorf("--aid is required for the mixed scenario")
+		}
+		return fritzbench.Mixed(flagAid, flagRawTemp), nil
+	default:
+		return nil, fmt.Errorf("unknown scenario %q, want one of devicelist, thermostat, switch, mixed", name)
+	}
+}
+
+func printTable(r *fritzbench.Result) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "METRIC\tVALUE")
+	fmt.Fprintf(w, "requests\t%d\n", r.Total)
+	fmt.Fprintf(w, "errors\t%d\n", r.Errors)
+	fmt.Fprintf(w, "throughput (req/s)\t%.2f\n", r.Throughput())
+	fmt.Fprintf(w, "p50 (ms)\t%.2f\n", r.P50)
+	fmt.Fprintf(w, "p90 (ms)\t%.2f\n", r.P90)
+	fmt.Fprintf(w, "p99 (ms)\t%.2f\n", r.P99)
+	fmt.Fprintf(w, "session reissues\t%d\n", r.SessionReissues)
+	for status, count := range r.StatusCounts {
+		fmt.Fprintf(w, "status %d\t%d\n", status, count)
+	}
+	return w.Flush()
+}
+
+func printJSON(r *fritzbench.Result) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,23 @@
+// Package cmd wires the fritzctl subcommands into a single cobra root command.
+package cmd
+
+import (
+	bench "github.com/bpicode/fritzctl/cmd/bench"
+	"github.com/spf13/cobra"
+)
+
+// RootCmd is the entry point for the fritzctl command line interface.
+var RootCmd = &cobra.Command{
+	Use:   "fritzctl",
+	Short: "fritzctl controls a FRITZ!Box from the command line",
+}
+
+func init() {
+	RootCmd.AddCommand(bench.Command)
+}
+
+// Execute runs the fritzctl command line interface, dispatching to the subcommand named on the
+// command line.
+func Execute() error {
+	return RootCmd.Execute()
+}
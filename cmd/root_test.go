@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBenchCommandIsRegistered tests that "fritzctl bench" is reachable from the root command,
+// guarding against the bench subcommand being implemented but never wired in.
+func TestBenchCommandIsRegistered(t *testing.T) {
+	cmd, _, err := RootCmd.Find([]string{"bench"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "bench", cmd.Name())
+}
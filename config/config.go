@@ -0,0 +1,104 @@
+// Package config defines the fritzctl configuration file format and the values derived from it
+// (FRITZ!Box URLs, PKI trust settings, login credentials and the resilient-transport retry
+// policy) that are consumed by the fritz package.
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the root of the fritzctl configuration file.
+type Config struct {
+	Box   Box   `yaml:"box"`   // Network location of the FRITZ!Box.
+	Login Login `yaml:"login"` // Credentials and authentication policy used to log in.
+	Pki   Pki   `yaml:"pki"`   // TLS trust settings for talking to the box.
+	Retry Retry `yaml:"retry"` // Resilient-transport retry policy, see fritz.Client.
+
+	loginURL string
+	ahaURL   string
+}
+
+// Box describes how to reach the FRITZ!Box on the network.
+type Box struct {
+	Protocol string `yaml:"protocol"` // "https" (default) or "http".
+	Host     string `yaml:"host"`     // Hostname or IP address, e.g. "fritz.box".
+}
+
+// Login holds the credentials and authentication policy used to log in to the box.
+type Login struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	AuthMode string `yaml:"auth_mode"` // One of fritz.AuthModeAuto/AuthModePBKDF2Only/AuthModeMD5Only. Empty behaves like AuthModeAuto.
+}
+
+// Pki controls how the TLS certificate presented by the box is validated.
+type Pki struct {
+	SkipTLSVerify   bool   `yaml:"skip_tls_verify"`
+	CertificateFile string `yaml:"certificate_file"`
+}
+
+// Retry configures the resilient-transport retry policy used by fritz.Client. Zero values fall
+// back to the package defaults, see the fritz package's retryPolicyFrom.
+type Retry struct {
+	MaxAttempts       int `yaml:"max_attempts"`        // Upper bound on the number of tries, including the first one.
+	MaxElapsedSeconds int `yaml:"max_elapsed_seconds"` // Upper bound on the total time spent retrying.
+	BaseDelayMillis   int `yaml:"base_delay_millis"`   // Upper bound for the jittered delay before the first retry.
+	MaxDelayMillis    int `yaml:"max_delay_millis"`    // Upper bound for the backoff delay.
+}
+
+// New reads and parses the configuration file at path.
+func New(path string) (*Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read configuration file %q: %w", path, err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse configuration file %q: %w", path, err)
+	}
+	cfg.deriveURLs()
+	return &cfg, nil
+}
+
+// deriveURLs computes the login and AHA URLs from Box, defaulting to a plain "fritz.box" over
+// HTTPS when unset.
+func (c *Config) deriveURLs() {
+	protocol := c.Box.Protocol
+	if protocol == "" {
+		protocol = "https"
+	}
+	host := c.Box.Host
+	if host == "" {
+		host = "fritz.box"
+	}
+	base := protocol + "://" + host
+	c.loginURL = base + "/login_sid.lua"
+	c.ahaURL = base + "/webservices/homeautoswitch.lua"
+}
+
+// GetLoginURL returns the URL of the login endpoint (login_sid.lua).
+func (c *Config) GetLoginURL() string {
+	if c.loginURL == "" {
+		c.deriveURLs()
+	}
+	return c.loginURL
+}
+
+// GetAhaURL returns the URL of the home automation endpoint (homeautoswitch.lua).
+func (c *Config) GetAhaURL() string {
+	if c.ahaURL == "" {
+		c.deriveURLs()
+	}
+	return c.ahaURL
+}
+
+// SetURLsForTest overrides the login and AHA URLs to point at base, e.g. the URL of an
+// httptest.Server. Exported so tests outside this package can point a Config at a fake box
+// without going through a config file; production code should configure Box instead.
+func (c *Config) SetURLsForTest(base string) {
+	c.loginURL = base + "/login_sid.lua"
+	c.ahaURL = base + "/webservices/homeautoswitch.lua"
+}
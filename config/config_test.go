@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetLoginURLDefaultsToFritzBox tests that an unconfigured Box falls back to a plain
+// "fritz.box" over HTTPS.
+func TestGetLoginURLDefaultsToFritzBox(t *testing.T) {
+	cfg := &Config{}
+	assert.Equal(t, "https://fritz.box/login_sid.lua", cfg.GetLoginURL())
+	assert.Equal(t, "https://fritz.box/webservices/homeautoswitch.lua", cfg.GetAhaURL())
+}
+
+// TestSetURLsForTestOverridesDerivedURLs tests that SetURLsForTest takes precedence over Box.
+func TestSetURLsForTestOverridesDerivedURLs(t *testing.T) {
+	cfg := &Config{Box: Box{Host: "192.168.178.1"}}
+	cfg.SetURLsForTest("http://127.0.0.1:1234")
+	assert.Equal(t, "http://127.0.0.1:1234/login_sid.lua", cfg.GetLoginURL())
+	assert.Equal(t, "http://127.0.0.1:1234/webservices/homeautoswitch.lua", cfg.GetAhaURL())
+}
+
+// TestNewParsesConfigFile tests that New reads and unmarshals a YAML configuration file,
+// including the retry policy introduced for the resilient transport.
+func TestNewParsesConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fritzctl.yaml")
+	contents := `
+box:
+  host: fritz.box
+login:
+  username: user
+  password: pass
+retry:
+  max_attempts: 7
+`
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+
+	cfg, err := New(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, "user", cfg.Login.Username)
+	assert.Equal(t, 7, cfg.Retry.MaxAttempts)
+	assert.Equal(t, "https://fritz.box/login_sid.lua", cfg.GetLoginURL())
+}
+
+// TestNewMissingFile tests that a missing configuration file is reported as an error.
+func TestNewMissingFile(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "does-not-exist.yaml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+// Package aggregator implements a running-aggregator for FRITZ!Box thermostat and switch
+// telemetry: a background poller collects samples at a short Interval, and an Aggregator
+// periodically rolls them up into per-device min/max/mean/last/uptime buckets that are handed
+// off to one or more Sinks. This lets long-running collectors chart heating and switch behavior
+// over days without a custom scraper for every backend.
+package aggregator
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/bpicode/fritzctl/logger"
+)
+
+// MetricsDropped counts samples whose timestamp fell outside the configured Grace/Delay window
+// of the period that was current when they arrived, and were therefore discarded instead of
+// aggregated.
+var MetricsDropped = expvar.NewInt("fritzctl_aggregator_metrics_dropped")
+
+// PollFunc retrieves the current set of telemetry samples from the FRITZ!Box, one per device.
+type PollFunc func(ctx context.Context) ([]Sample, error)
+
+// Config configures the aggregator's polling and aggregation cadence.
+type Config struct {
+	Interval time.Duration // How often Poll is invoked.
+	Period   time.Duration // How often aggregated buckets are flushed to the sinks.
+	Grace    time.Duration // How far before a period's start a sample may still count towards it.
+	Delay    time.Duration // How long after a period's end a late sample may still count towards it.
+}
+
+// Aggregator polls telemetry at Config.Interval and emits aggregated buckets at Config.Period.
+type Aggregator struct {
+	Config Config
+	Poll   PollFunc
+	Sinks  []Sink
+
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	periodStart time.Time
+	periodEnd   time.Time
+}
+
+// New creates an Aggregator with the given configuration, poll function and sinks.
+func New(cfg Config, poll PollFunc, sinks ...Sink) *Aggregator {
+	return &Aggregator{Config: cfg, Poll: poll, Sinks: sinks, buckets: make(map[string]*bucket)}
+}
+
+// Run polls and aggregates until ctx is cancelled, returning ctx.Err() at that point.
+func (a *Aggregator) Run(ctx context.Context) error {
+	now := time.Now()
+	a.mu.Lock()
+	a.periodStart = now
+	a.periodEnd = now.Add(a.Config.Period)
+	a.mu.Unlock()
+
+	pollTicker := time.NewTicker(a.Config.Interval)
+	defer pollTicker.Stop()
+	periodTicker := time.NewTicker(a.Config.Period)
+	defer periodTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-pollTicker.C:
+			a.pollOnce(ctx)
+		case flushedAt := <-periodTicker.C:
+			a.flush(flushedAt)
+		}
+	}
+}
+
+func (a *Aggregator) pollOnce(ctx context.Context) {
+	samples, err := a.Poll(ctx)
+	if err != nil {
+		logger.Debug("aggregator: poll failed:", err)
+		return
+	}
+	for _, s := range samples {
+		a.record(s)
+	}
+}
+
+// record attributes a sample to the current period's bucket, or drops it if its timestamp falls
+// outside [periodStart-Grace, periodEnd+Delay].
+func (a *Aggregator) record(s Sample) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	lowerBound := a.periodStart.Add(-a.Config.Grace)
+	upperBound := a.periodEnd.Add(a.Config.Delay)
+	if s.Timestamp.Before(lowerBound) || s.Timestamp.After(upperBound) {
+		MetricsDropped.Add(1)
+		logger.Debug("aggregator: dropping sample for", s.DeviceID, "outside window", lowerBound, upperBound)
+		return
+	}
+
+	b, ok := a.buckets[s.DeviceID]
+	if !ok {
+		b = newBucket()
+		a.buckets[s.DeviceID] = b
+	}
+	b.add(s)
+}
+
+// flush closes out the current period, handing its aggregated metrics to every configured sink,
+// and opens the next period starting at flushedAt.
+func (a *Aggregator) flush(flushedAt time.Time) {
+	a.mu.Lock()
+	buckets := a.buckets
+	periodStart, periodEnd := a.periodStart, a.periodEnd
+	a.buckets = make(map[string]*bucket)
+	a.periodStart = flushedAt
+	a.periodEnd = flushedAt.Add(a.Config.Period)
+	a.mu.Unlock()
+
+	for deviceID, b := range buckets {
+		for _, m := range b.finalize(deviceID, periodStart, periodEnd) {
+			for _, sink := range a.Sinks {
+				if err := sink.Write(m); err != nil {
+					logger.Warn("aggregator: sink write failed:", err)
+				}
+			}
+		}
+	}
+}
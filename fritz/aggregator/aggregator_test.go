@@ -0,0 +1,27 @@
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRecordDropsSamplesOutsideWindow tests that a sample timestamped outside
+// [periodStart-Grace, periodEnd+Delay] is discarded and counted in MetricsDropped rather than
+// aggregated into a bucket.
+func TestRecordDropsSamplesOutsideWindow(t *testing.T) {
+	a := New(Config{Interval: time.Second, Period: time.Minute, Grace: time.Second, Delay: time.Second}, nil)
+	now := time.Now()
+	a.periodStart = now
+	a.periodEnd = now.Add(time.Minute)
+
+	before := MetricsDropped.Value()
+	a.record(NewSwitchSample("switch-1", now.Add(-2*time.Second), 10, 0))
+	a.record(NewSwitchSample("switch-1", now.Add(2*time.Minute), 10, 0))
+	assert.Equal(t, before+2, MetricsDropped.Value())
+	assert.Empty(t, a.buckets)
+
+	a.record(NewSwitchSample("switch-1", now, 10, 0))
+	assert.Len(t, a.buckets, 1)
+}
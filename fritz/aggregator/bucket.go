@@ -0,0 +1,115 @@
+package aggregator
+
+import (
+	"math"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// Metric is one aggregated bucket of telemetry for a single device over a period.
+type Metric struct {
+	DeviceID    string    // AIN/identifier of the device the metric belongs to.
+	PeriodStart time.Time // Start of the aggregation period.
+	PeriodEnd   time.Time // End of the aggregation period.
+	Field       string    // "measured", "goal", "comfort", "saving", "power", "energy" or "uptime".
+	Min         float64   // Smallest value observed during the period.
+	Max         float64   // Largest value observed during the period.
+	Mean        float64   // Arithmetic mean of the values observed during the period.
+	Last        float64   // Most recently observed value.
+	Samples     int       // Number of samples this metric is derived from.
+}
+
+// bucket accumulates the samples of a single device over one aggregation period.
+type bucket struct {
+	fields       map[string]*fieldStats
+	onSamples    int
+	stateSamples int
+}
+
+func newBucket() *bucket {
+	return &bucket{fields: make(map[string]*fieldStats)}
+}
+
+func (b *bucket) add(s Sample) {
+	if s.Thermostat != nil {
+		b.addThermostat(s.Thermostat)
+	}
+	if !math.IsNaN(s.PowerW) {
+		b.field("power").add(s.PowerW)
+		b.stateSamples++
+		if s.PowerW > 0 {
+			b.onSamples++
+		}
+	}
+	if !math.IsNaN(s.EnergyWh) {
+		b.field("energy").add(s.EnergyWh)
+	}
+}
+
+func (b *bucket) addThermostat(t *fritz.Thermostat) {
+	for field, raw := range map[string]string{
+		"measured": t.Measured, "goal": t.Goal, "comfort": t.Comfort, "saving": t.Saving,
+	} {
+		if v, ok := realTemperature(raw); ok {
+			b.field(field).add(v)
+		}
+	}
+	switch t.State() {
+	case 1:
+		b.onSamples++
+		b.stateSamples++
+	case 0:
+		b.stateSamples++
+	}
+}
+
+func (b *bucket) field(name string) *fieldStats {
+	fs, ok := b.fields[name]
+	if !ok {
+		fs = &fieldStats{}
+		b.fields[name] = fs
+	}
+	return fs
+}
+
+// finalize returns the aggregated metrics for this bucket, one per observed field plus a
+// synthetic "uptime" field derived from the ON/OFF ratio of the period's samples.
+func (b *bucket) finalize(deviceID string, start, end time.Time) []Metric {
+	metrics := make([]Metric, 0, len(b.fields)+1)
+	for field, fs := range b.fields {
+		if fs.count == 0 {
+			continue
+		}
+		metrics = append(metrics, Metric{
+			DeviceID: deviceID, PeriodStart: start, PeriodEnd: end, Field: field,
+			Min: fs.min, Max: fs.max, Mean: fs.sum / float64(fs.count), Last: fs.last, Samples: fs.count,
+		})
+	}
+	if b.stateSamples > 0 {
+		uptime := float64(b.onSamples) / float64(b.stateSamples)
+		metrics = append(metrics, Metric{
+			DeviceID: deviceID, PeriodStart: start, PeriodEnd: end, Field: "uptime",
+			Min: uptime, Max: uptime, Mean: uptime, Last: uptime, Samples: b.stateSamples,
+		})
+	}
+	return metrics
+}
+
+// fieldStats tracks the running min/max/mean/last of one field within a bucket.
+type fieldStats struct {
+	min, max, sum, last float64
+	count               int
+}
+
+func (fs *fieldStats) add(v float64) {
+	if fs.count == 0 || v < fs.min {
+		fs.min = v
+	}
+	if fs.count == 0 || v > fs.max {
+		fs.max = v
+	}
+	fs.sum += v
+	fs.last = v
+	fs.count++
+}
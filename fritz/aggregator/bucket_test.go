@@ -0,0 +1,59 @@
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBucketThermostatSpecialValues tests that the AHA special values 253/254/255 are handled
+// as OFF/ON/undefined instead of being averaged in as temperatures.
+func TestBucketThermostatSpecialValues(t *testing.T) {
+	b := newBucket()
+	b.addThermostat(&fritz.Thermostat{Measured: "44", Goal: "42", Comfort: "44", Saving: "40"})
+	b.addThermostat(&fritz.Thermostat{Measured: "253", Goal: "253", Comfort: "253", Saving: "253"})
+	b.addThermostat(&fritz.Thermostat{Measured: "254", Goal: "254", Comfort: "254", Saving: "254"})
+	b.addThermostat(&fritz.Thermostat{Measured: "255", Goal: "255", Comfort: "255", Saving: "255"})
+
+	metrics := b.finalize("thermostat-1", time.Unix(0, 0), time.Unix(60, 0))
+
+	byField := indexByField(metrics)
+	measured := byField["measured"]
+	assert.Equal(t, 1, measured.Samples, "only the plain numeric reading should count towards the mean")
+	assert.Equal(t, 44.0, measured.Mean)
+
+	uptime := byField["uptime"]
+	assert.Equal(t, 3, uptime.Samples, "253 (OFF) and 254 (ON) both count towards uptime, 255 does not")
+	assert.InDelta(t, 2.0/3.0, uptime.Last, 1e-9)
+}
+
+// TestBucketSwitchFields tests min/max/mean/last aggregation of switch power and energy
+// readings.
+func TestBucketSwitchFields(t *testing.T) {
+	b := newBucket()
+	b.add(NewSwitchSample("switch-1", time.Unix(0, 0), 10, 100))
+	b.add(NewSwitchSample("switch-1", time.Unix(1, 0), 30, 110))
+	b.add(NewSwitchSample("switch-1", time.Unix(2, 0), 0, 120))
+
+	metrics := b.finalize("switch-1", time.Unix(0, 0), time.Unix(60, 0))
+	byField := indexByField(metrics)
+
+	power := byField["power"]
+	assert.Equal(t, 0.0, power.Min)
+	assert.Equal(t, 30.0, power.Max)
+	assert.Equal(t, float64(40)/3, power.Mean)
+	assert.Equal(t, 0.0, power.Last)
+
+	uptime := byField["uptime"]
+	assert.InDelta(t, 2.0/3.0, uptime.Last, 1e-9, "two of three samples drew non-zero power")
+}
+
+func indexByField(metrics []Metric) map[string]Metric {
+	index := make(map[string]Metric, len(metrics))
+	for _, m := range metrics {
+		index[m.Field] = m
+	}
+	return index
+}
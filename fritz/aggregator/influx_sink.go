@@ -0,0 +1,37 @@
+package aggregator
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// InfluxSink writes each Metric as one InfluxDB line-protocol record to an underlying writer.
+type InfluxSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewInfluxSink creates an InfluxSink writing line-protocol records to w.
+func NewInfluxSink(w io.Writer) *InfluxSink {
+	return &InfluxSink{w: w}
+}
+
+// Write appends m to the sink as a single InfluxDB line-protocol record, timestamped at the end
+// of the aggregation period in nanoseconds since the epoch.
+func (s *InfluxSink) Write(m Metric) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	line := fmt.Sprintf(
+		"telemetry,device=%s,field=%s min=%g,max=%g,mean=%g,last=%g,samples=%di %d\n",
+		escapeTag(m.DeviceID), escapeTag(m.Field), m.Min, m.Max, m.Mean, m.Last, m.Samples, m.PeriodEnd.UnixNano(),
+	)
+	_, err := io.WriteString(s.w, line)
+	return err
+}
+
+// escapeTag escapes the characters InfluxDB line protocol treats as special in tag keys/values.
+func escapeTag(s string) string {
+	return strings.NewReplacer(" ", "\\ ", ",", "\\,", "=", "\\=").Replace(s)
+}
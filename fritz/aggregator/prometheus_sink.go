@@ -0,0 +1,46 @@
+package aggregator
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// PrometheusSink keeps the most recently flushed Metric per (device, field) in memory and
+// exposes them in the Prometheus text exposition format via ServeHTTP.
+type PrometheusSink struct {
+	mu      sync.RWMutex
+	metrics map[string]Metric
+}
+
+// NewPrometheusSink creates an empty PrometheusSink.
+func NewPrometheusSink() *PrometheusSink {
+	return &PrometheusSink{metrics: make(map[string]Metric)}
+}
+
+// Write records m as the latest value for its (device, field) pair.
+func (s *PrometheusSink) Write(m Metric) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics[m.DeviceID+"/"+m.Field] = m
+	return nil
+}
+
+// ServeHTTP renders the most recently written metrics in the Prometheus exposition format. It
+// is meant to be registered under a path such as "/metrics".
+func (s *PrometheusSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, m := range s.metrics {
+		for _, gauge := range []struct {
+			suffix string
+			value  float64
+		}{
+			{"min", m.Min}, {"max", m.Max}, {"mean", m.Mean}, {"last", m.Last},
+		} {
+			fmt.Fprintf(w, "fritzctl_telemetry_%s{device=%q,field=%q} %g\n", gauge.suffix, m.DeviceID, m.Field, gauge.value)
+		}
+		fmt.Fprintf(w, "fritzctl_telemetry_samples{device=%q,field=%q} %d\n", m.DeviceID, m.Field, m.Samples)
+	}
+}
@@ -0,0 +1,40 @@
+package aggregator
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// Sample is a single point-in-time telemetry reading for one device.
+type Sample struct {
+	DeviceID   string            // AIN/identifier of the device.
+	Timestamp  time.Time         // When the sample was taken.
+	Thermostat *fritz.Thermostat // Thermostat reading, nil if the device is not a thermostat.
+	PowerW     float64           // Switch power reading in watts, math.NaN() if not applicable.
+	EnergyWh   float64           // Switch cumulative energy reading in watt-hours, math.NaN() if not applicable.
+}
+
+// NewSwitchSample builds a Sample for a switch device, leaving the Thermostat field unset.
+func NewSwitchSample(deviceID string, timestamp time.Time, powerW, energyWh float64) Sample {
+	return Sample{DeviceID: deviceID, Timestamp: timestamp, PowerW: powerW, EnergyWh: energyWh}
+}
+
+// NewThermostatSample builds a Sample for a thermostat device, leaving PowerW/EnergyWh unset.
+func NewThermostatSample(deviceID string, timestamp time.Time, t *fritz.Thermostat) Sample {
+	return Sample{DeviceID: deviceID, Timestamp: timestamp, Thermostat: t, PowerW: math.NaN(), EnergyWh: math.NaN()}
+}
+
+// realTemperature parses one of Thermostat's raw AHA fields (Measured/Goal/Comfort/Saving) and
+// reports whether it is an ordinary temperature reading. The special values defined by the AHA
+// interface are excluded here rather than treated as numbers: 255 is undefined, 253 marks the
+// device as OFF and 254 marks it as ON, neither of which is a temperature to average.
+func realTemperature(raw string) (float64, bool) {
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v >= 253 {
+		return 0, false
+	}
+	return v, true
+}
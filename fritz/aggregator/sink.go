@@ -0,0 +1,33 @@
+package aggregator
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Sink receives aggregated Metrics as they are flushed by an Aggregator. Implementations must
+// be safe for concurrent use, since a single Aggregator may write to several sinks from its
+// flush goroutine while sinks such as PrometheusSink are read concurrently by an HTTP handler.
+type Sink interface {
+	Write(Metric) error
+}
+
+// FileSink appends one JSON object per Metric to an underlying writer, newline-delimited so the
+// result can be tailed or processed line by line.
+type FileSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewFileSink creates a FileSink writing JSON lines to w.
+func NewFileSink(w io.Writer) *FileSink {
+	return &FileSink{w: w}
+}
+
+// Write appends m to the sink as a single line of JSON.
+func (s *FileSink) Write(m Metric) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.NewEncoder(s.w).Encode(m)
+}
@@ -0,0 +1,24 @@
+package fritz
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Do performs a context-aware, authenticated GET request against the home automation endpoint
+// (homeautoswitch.lua) for the given switchcmd and additional query parameters. Like the
+// requests issued internally by the higher-level accessors in this package, it retries
+// transient failures and re-logs in once if the session has expired. It is exported so tooling
+// that needs to drive the AHA interface directly, such as fritzctl bench, doesn't have to
+// reimplement retry and relogin handling.
+func (client *Client) Do(ctx context.Context, switchcmd string, params map[string]string) (*http.Response, error) {
+	q := url.Values{}
+	q.Set("switchcmd", switchcmd)
+	q.Set("sid", client.sid())
+	for k, v := range params {
+		q.Set(k, v)
+	}
+	rawURL := client.Config.GetAhaURL() + "?" + q.Encode()
+	return client.doAuthenticated(ctx, rawURL)
+}
@@ -0,0 +1,43 @@
+package bench
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Result summarizes one benchmark Run.
+type Result struct {
+	Total           int           // Total requests attempted.
+	Errors          int           // Requests that failed outright (transport error, no HTTP status).
+	StatusCounts    map[int]int   // Completed requests broken down by HTTP status code.
+	SessionReissues int64         // Number of times the client had to re-login during the run.
+	Duration        time.Duration // Wall-clock time the run actually took.
+	P50, P90, P99   float64       // Latency percentiles, in milliseconds.
+}
+
+// Throughput returns the average number of requests completed per second.
+func (r *Result) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Total) / r.Duration.Seconds()
+}
+
+// percentile returns the p-th percentile (0-100) of values, or 0 if values is empty. The slice
+// is copied before sorting so the caller's ordering is left intact.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	index := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return sorted[index]
+}
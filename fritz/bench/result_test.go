@@ -0,0 +1,16 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPercentile tests percentile computation over a handful of known distributions.
+func TestPercentile(t *testing.T) {
+	values := []float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
+	assert.Equal(t, 50.0, percentile(values, 50))
+	assert.Equal(t, 90.0, percentile(values, 90))
+	assert.Equal(t, 100.0, percentile(values, 99))
+	assert.Equal(t, 0.0, percentile(nil, 50))
+}
@@ -0,0 +1,100 @@
+package bench
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// Config configures a benchmark Run.
+type Config struct {
+	Concurrency int           // Number of workers driving Scenario concurrently.
+	Duration    time.Duration // Wall-clock time to run for.
+	RPS         float64       // Upper bound on requests per second across all workers, 0 = uncapped.
+}
+
+// Run drives scenario against client with the given concurrency for the configured duration,
+// optionally capped at Config.RPS requests per second, and returns latency, throughput and
+// error statistics. Run reuses the passed-in, already logged-in client for every request, so the
+// retry and relogin handling in fritz.Client is exercised exactly as it would be in production.
+func Run(ctx context.Context, client *fritz.Client, scenario Scenario, cfg Config) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var limiter <-chan time.Time
+	if cfg.RPS > 0 {
+		ticker := time.NewTicker(rpsInterval(cfg.RPS))
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+	reissuesBefore := client.SessionReissues()
+	start := time.Now()
+
+	var mu sync.Mutex
+	statusCounts := make(map[int]int)
+	var latenciesMillis []float64
+	var total, errors int64
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < cfg.Concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if limiter != nil {
+					select {
+					case <-ctx.Done():
+						return
+					case <-limiter:
+					}
+				} else if ctx.Err() != nil {
+					return
+				}
+
+				requestStart := time.Now()
+				status, err := scenario(ctx, client)
+				elapsed := time.Since(requestStart)
+
+				atomic.AddInt64(&total, 1)
+				mu.Lock()
+				latenciesMillis = append(latenciesMillis, float64(elapsed.Microseconds())/1000)
+				if err != nil {
+					atomic.AddInt64(&errors, 1)
+				} else {
+					statusCounts[status]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	result := &Result{
+		Total:           int(total),
+		Errors:          int(errors),
+		StatusCounts:    statusCounts,
+		SessionReissues: client.SessionReissues() - reissuesBefore,
+		Duration:        time.Since(start),
+		P50:             percentile(latenciesMillis, 50),
+		P90:             percentile(latenciesMillis, 90),
+		P99:             percentile(latenciesMillis, 99),
+	}
+	return result, nil
+}
+
+// rpsInterval returns the delay between ticks needed to cap the aggregate request rate at rps,
+// which must be > 0. time.NewTicker panics on a non-positive duration, which float64(time.Second)
+// / rps would otherwise produce for very large rps values (the division rounding to 0ns); the
+// result is floored at 1ns so callers always get a valid ticker, at the cost of no longer
+// enforcing the cap precisely at such extreme rates.
+func rpsInterval(rps float64) time.Duration {
+	interval := time.Duration(float64(time.Second) / rps)
+	if interval < time.Nanosecond {
+		return time.Nanosecond
+	}
+	return interval
+}
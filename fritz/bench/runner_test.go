@@ -0,0 +1,17 @@
+package bench
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRPSIntervalFloorsAtOneNanosecond tests that rpsInterval never returns a non-positive
+// duration, which would make time.NewTicker panic, even for very large --rps values.
+func TestRPSIntervalFloorsAtOneNanosecond(t *testing.T) {
+	assert.Equal(t, time.Second, rpsInterval(1))
+	assert.Equal(t, 10*time.Millisecond, rpsInterval(100))
+	assert.Equal(t, time.Nanosecond, rpsInterval(1e12))
+	assert.Equal(t, time.Nanosecond, rpsInterval(1e18))
+}
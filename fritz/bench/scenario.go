@@ -0,0 +1,66 @@
+// Package bench drives the authenticated AHA HTTP endpoints of a FRITZ!Box under configurable
+// concurrency and reports latency, throughput and error statistics, so users can characterize
+// what their box actually tolerates before it starts throwing 500s.
+package bench
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+
+	"github.com/bpicode/fritzctl/fritz"
+)
+
+// Scenario is one unit of load-test work executed repeatedly against an authenticated Client. It
+// returns the HTTP status code of the request it made, so a Result can report a status
+// breakdown, or a non-nil error if the request could not be completed at all (transport
+// failure).
+type Scenario func(ctx context.Context, client *fritz.Client) (status int, err error)
+
+// DeviceList repeatedly calls getdevicelistinfos, the read scenario most FRITZ!Box integrations
+// hit hardest.
+func DeviceList() Scenario {
+	return func(ctx context.Context, client *fritz.Client) (int, error) {
+		return do(ctx, client, "getdevicelistinfos", nil)
+	}
+}
+
+// ThermostatWrite repeatedly sets the target temperature of the thermostat identified by aid to
+// rawTemp, a raw AHA temperature value as accepted by sethkrtsoll.
+func ThermostatWrite(aid, rawTemp string) Scenario {
+	return func(ctx context.Context, client *fritz.Client) (int, error) {
+		return do(ctx, client, "sethkrtsoll", map[string]string{"ain": aid, "param": rawTemp})
+	}
+}
+
+// SwitchToggle repeatedly flips the switch identified by aid on and off.
+func SwitchToggle(aid string) Scenario {
+	var calls uint64
+	return func(ctx context.Context, client *fritz.Client) (int, error) {
+		cmd := "setswitchoff"
+		if atomic.AddUint64(&calls, 1)%2 == 1 {
+			cmd = "setswitchon"
+		}
+		return do(ctx, client, cmd, map[string]string{"ain": aid})
+	}
+}
+
+// Mixed cycles through a read/write workload: device list, thermostat write, switch toggle.
+func Mixed(aid, rawTemp string) Scenario {
+	scenarios := []Scenario{DeviceList(), ThermostatWrite(aid, rawTemp), SwitchToggle(aid)}
+	var calls uint64
+	return func(ctx context.Context, client *fritz.Client) (int, error) {
+		n := atomic.AddUint64(&calls, 1)
+		return scenarios[int(n)%len(scenarios)](ctx, client)
+	}
+}
+
+func do(ctx context.Context, client *fritz.Client, switchcmd string, params map[string]string) (int, error) {
+	resp, err := client.Do(ctx, switchcmd, params)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,142 @@
+package fritz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/zalando/go-keyring"
+)
+
+// Values accepted by config.Login.AuthMode.
+const (
+	AuthModeAuto       = "auto"        // Use PBKDF2 if the box offers it, fall back to MD5 otherwise. Default.
+	AuthModePBKDF2Only = "pbkdf2-only" // Refuse to answer a non-PBKDF2 challenge, see ErrWeakAuthRejected.
+	AuthModeMD5Only    = "md5-only"    // Always answer with MD5, for legacy boxes that mishandle PBKDF2.
+)
+
+// ErrWeakAuthRejected is returned by Login when config.Login.AuthMode is AuthModePBKDF2Only and
+// the FRITZ!Box only offered an MD5 challenge.
+var ErrWeakAuthRejected = errors.New("fritz: FRITZ!Box offered a weak (non-PBKDF2) challenge, refusing to answer it because AuthMode is pbkdf2-only")
+
+// CredentialProvider supplies the username and password used to answer a FRITZ!Box login
+// challenge. It is consulted lazily on every Login, so short-lived secrets, rotated passwords
+// and hardware tokens are possible without restarting the Client.
+type CredentialProvider interface {
+	// Username returns the login name to authenticate with.
+	Username(ctx context.Context) (string, error)
+	// Password returns the secret used to answer challenge.
+	Password(ctx context.Context, challenge string) (string, error)
+}
+
+// zero best-effort scrubs a password once it has been consumed by clearing the given reference.
+// Go strings are immutable and may have been copied by the runtime, so the underlying bytes are
+// not overwritten. Providers that hold the password themselves, such as StaticCredentials reading
+// it from config.Config, still keep it.
+func zero(password *string) {
+	*password = ""
+}
+
+// staticCredentialProvider reads the username and password straight from a config.Config, the
+// behavior every Client had before CredentialProvider existed.
+type staticCredentialProvider struct {
+	cfg *config.Config
+}
+
+// StaticCredentials returns a CredentialProvider serving the username/password configured in
+// cfg.Login.
+func StaticCredentials(cfg *config.Config) CredentialProvider {
+	return &staticCredentialProvider{cfg: cfg}
+}
+
+func (p *staticCredentialProvider) Username(ctx context.Context) (string, error) {
+	return p.cfg.Login.Username, nil
+}
+
+func (p *staticCredentialProvider) Password(ctx context.Context, challenge string) (string, error) {
+	return p.cfg.Login.Password, nil
+}
+
+// envCredentialProvider reads the username/password from environment variables on every call.
+type envCredentialProvider struct {
+	usernameVar string
+	passwordVar string
+}
+
+// EnvCredentials returns a CredentialProvider reading the username from the environment
+// variable usernameVar and the password from passwordVar.
+func EnvCredentials(usernameVar, passwordVar string) CredentialProvider {
+	return &envCredentialProvider{usernameVar: usernameVar, passwordVar: passwordVar}
+}
+
+func (p *envCredentialProvider) Username(ctx context.Context) (string, error) {
+	return lookupEnv(p.usernameVar)
+}
+
+func (p *envCredentialProvider) Password(ctx context.Context, challenge string) (string, error) {
+	return lookupEnv(p.passwordVar)
+}
+
+func lookupEnv(name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return v, nil
+}
+
+// keyringCredentialProvider reads the password for a fixed username from the OS-native keyring
+// via github.com/zalando/go-keyring.
+type keyringCredentialProvider struct {
+	service  string
+	username string
+}
+
+// KeyringCredentials returns a CredentialProvider that looks up the password for username in
+// the OS keyring under service, e.g. service "fritzctl".
+func KeyringCredentials(service, username string) CredentialProvider {
+	return &keyringCredentialProvider{service: service, username: username}
+}
+
+func (p *keyringCredentialProvider) Username(ctx context.Context) (string, error) {
+	return p.username, nil
+}
+
+func (p *keyringCredentialProvider) Password(ctx context.Context, challenge string) (string, error) {
+	secret, err := keyring.Get(p.service, p.username)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password from OS keyring: %w", err)
+	}
+	return secret, nil
+}
+
+// execCredentialProvider obtains the password by running an external command and reading its
+// trimmed stdout, in the style of git-credential helpers.
+type execCredentialProvider struct {
+	username string
+	command  string
+	args     []string
+}
+
+// ExecCredentials returns a CredentialProvider that runs command (with args) to obtain the
+// password, while username is served as configured. The command is re-run on every Login and
+// must print the password on a single line of stdout.
+func ExecCredentials(username, command string, args ...string) CredentialProvider {
+	return &execCredentialProvider{username: username, command: command, args: args}
+}
+
+func (p *execCredentialProvider) Username(ctx context.Context) (string, error) {
+	return p.username, nil
+}
+
+func (p *execCredentialProvider) Password(ctx context.Context, challenge string) (string, error) {
+	out, err := exec.CommandContext(ctx, p.command, p.args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("credential command %q failed: %w", p.command, err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
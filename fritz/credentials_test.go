@@ -0,0 +1,125 @@
+package fritz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSolveChallengePBKDF2OnlyRejectsWeakChallenge tests that AuthModePBKDF2Only refuses to
+// answer a challenge the box did not offer in PBKDF2 form, without ever contacting the box.
+func TestSolveChallengePBKDF2OnlyRejectsWeakChallenge(t *testing.T) {
+	client := NewClientFromConfig(&config.Config{Login: config.Login{AuthMode: AuthModePBKDF2Only}})
+	sessionInfo := &SessionInfo{Challenge: "legacy-challenge", IsPBKDF2: false}
+
+	err := client.solveChallenge(context.Background(), sessionInfo)
+
+	assert.ErrorIs(t, err, ErrWeakAuthRejected)
+}
+
+// TestSolveChallengeMD5OnlyForcesMD5EvenWhenPBKDF2Offered tests that AuthModeMD5Only answers
+// with the MD5 scheme even though the box's challenge advertises PBKDF2 support.
+func TestSolveChallengeMD5OnlyForcesMD5EvenWhenPBKDF2Offered(t *testing.T) {
+	challenge := "2$10$1234abcd$10$5678ef01"
+	var gotResponse string
+	srv := newLoginStub(t, &gotResponse)
+	defer srv.Close()
+
+	client := NewClientFromConfig(&config.Config{Login: config.Login{AuthMode: AuthModeMD5Only, Username: "u", Password: "p"}})
+	client.Config.SetURLsForTest(srv.URL)
+	sessionInfo := &SessionInfo{Challenge: challenge, IsPBKDF2: true}
+
+	err := client.solveChallenge(context.Background(), sessionInfo)
+
+	require.NoError(t, err)
+	assert.Equal(t, calculateMD5Response(challenge, "p"), gotResponse)
+}
+
+// TestSolveChallengeAutoUsesPBKDF2WhenOffered tests that the default "auto" mode answers with
+// PBKDF2 whenever the challenge advertises it, rather than falling back to MD5.
+func TestSolveChallengeAutoUsesPBKDF2WhenOffered(t *testing.T) {
+	challenge := "2$10$1234abcd$10$5678ef01"
+	var gotResponse string
+	srv := newLoginStub(t, &gotResponse)
+	defer srv.Close()
+
+	client := NewClientFromConfig(&config.Config{Login: config.Login{Username: "u", Password: "p"}})
+	client.Config.SetURLsForTest(srv.URL)
+	sessionInfo := &SessionInfo{Challenge: challenge, IsPBKDF2: true}
+
+	err := client.solveChallenge(context.Background(), sessionInfo)
+
+	require.NoError(t, err)
+	want, pbkdf2Err := calculatePBKDF2Response(challenge, "p")
+	require.NoError(t, pbkdf2Err)
+	assert.Equal(t, want, gotResponse)
+}
+
+// newLoginStub starts a server that records the "response" form value of the login POST into
+// *gotResponse and answers with a valid session id.
+func newLoginStub(t *testing.T, gotResponse *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		*gotResponse = r.Form.Get("response")
+		fmt.Fprint(w, `<SessionInfo><SID>1234567890123456</SID></SessionInfo>`)
+	}))
+}
+
+// TestEnvCredentialProviderReadsConfiguredVariables tests that username and password are read
+// from the configured environment variables on every call.
+func TestEnvCredentialProviderReadsConfiguredVariables(t *testing.T) {
+	t.Setenv("FRITZCTL_TEST_USER", "bob")
+	t.Setenv("FRITZCTL_TEST_PASS", "secret")
+	provider := EnvCredentials("FRITZCTL_TEST_USER", "FRITZCTL_TEST_PASS")
+
+	username, err := provider.Username(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "bob", username)
+
+	password, err := provider.Password(context.Background(), "any-challenge")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", password)
+}
+
+// TestEnvCredentialProviderMissingVariable tests that an unset environment variable is reported
+// as an error rather than silently returning an empty credential.
+func TestEnvCredentialProviderMissingVariable(t *testing.T) {
+	os.Unsetenv("FRITZCTL_TEST_MISSING")
+	provider := EnvCredentials("FRITZCTL_TEST_MISSING", "FRITZCTL_TEST_MISSING")
+
+	_, err := provider.Username(context.Background())
+
+	assert.Error(t, err)
+}
+
+// TestExecCredentialProviderReadsCommandOutput tests that the password is the trimmed stdout of
+// the configured command, in the style of git-credential helpers.
+func TestExecCredentialProviderReadsCommandOutput(t *testing.T) {
+	if _, err := exec.LookPath("printf"); err != nil {
+		t.Skip("printf not available")
+	}
+	provider := ExecCredentials("bob", "printf", "secret\n")
+
+	password, err := provider.Password(context.Background(), "any-challenge")
+
+	require.NoError(t, err)
+	assert.Equal(t, "secret", password)
+}
+
+// TestExecCredentialProviderCommandFailure tests that a failing command is surfaced as an error
+// rather than an empty password.
+func TestExecCredentialProviderCommandFailure(t *testing.T) {
+	provider := ExecCredentials("bob", "false")
+
+	_, err := provider.Password(context.Background(), "any-challenge")
+
+	assert.Error(t, err)
+}
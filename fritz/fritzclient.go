@@ -1,6 +1,7 @@
 package fritz
 
 import (
+	"context"
 	"crypto/md5"
 	"crypto/sha256"
 	"crypto/tls"
@@ -14,6 +15,8 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bpicode/fritzctl/config"
@@ -25,9 +28,39 @@ import (
 
 // Client encapsulates the FRITZ!Box interaction API.
 type Client struct {
-	Config      *config.Config // The client configuration.
-	HTTPClient  *http.Client   // The HTTP client.
-	SessionInfo *SessionInfo   // The current session data of the client.
+	Config      *config.Config     // The client configuration.
+	HTTPClient  *http.Client       // The HTTP client.
+	SessionInfo *SessionInfo       // The current session data of the client. Guarded by sessionMu, use sid()/setSessionInfo to access it concurrently.
+	Credentials CredentialProvider // Supplies username/password on every Login, defaults to the static values from Config.Login.
+
+	sessionMu       sync.RWMutex // Guards SessionInfo against concurrent reads (query building) and writes (LoginCtx).
+	sessionReissues int64        // Atomic counter of relogin-on-expired-session events, see SessionReissues.
+}
+
+// sid returns the session id of the currently active session, or the empty string if the client
+// has not logged in yet. Safe for concurrent use with setSessionInfo, unlike reading
+// client.SessionInfo directly.
+func (client *Client) sid() string {
+	client.sessionMu.RLock()
+	defer client.sessionMu.RUnlock()
+	if client.SessionInfo == nil {
+		return ""
+	}
+	return client.SessionInfo.SID
+}
+
+// setSessionInfo atomically replaces the client's SessionInfo. Safe for concurrent use with sid(),
+// unlike assigning to client.SessionInfo directly.
+func (client *Client) setSessionInfo(info *SessionInfo) {
+	client.sessionMu.Lock()
+	defer client.sessionMu.Unlock()
+	client.SessionInfo = info
+}
+
+// SessionReissues reports how many times this Client has had to transparently re-login because
+// the FRITZ!Box considered its session expired (HTTP 403 on an authenticated endpoint).
+func (client *Client) SessionReissues() int64 {
+	return atomic.LoadInt64(&client.sessionReissues)
 }
 
 // SessionInfo models the xml upon accessing the login endpoint.
@@ -62,36 +95,59 @@ func NewClient(configfile string) (*Client, error) {
 	return NewClientFromConfig(cfg), nil
 }
 
-// NewClientFromConfig creates a new Client with the passed configuration.
+// NewClientFromConfig creates a new Client with the passed configuration. Credentials defaults
+// to the static username/password configured in cfg.Login; use a custom CredentialProvider on
+// the returned Client to source credentials elsewhere.
 func NewClientFromConfig(cfg *config.Config) *Client {
 	tlsConfig := tlsConfigFrom(cfg)
 	transport := &http.Transport{TLSClientConfig: tlsConfig}
 	httpClient := &http.Client{Transport: transport}
-	return &Client{Config: cfg, HTTPClient: httpClient}
+	return &Client{Config: cfg, HTTPClient: httpClient, Credentials: StaticCredentials(cfg)}
+}
+
+// credentials returns the client's CredentialProvider, falling back to the static values from
+// Config.Login for Clients constructed without NewClientFromConfig.
+func (client *Client) credentials() CredentialProvider {
+	if client.Credentials != nil {
+		return client.Credentials
+	}
+	return StaticCredentials(client.Config)
 }
 
 // Login tries to login into the box and obtain the session id.
-// https://avm.de/fileadmin/user_upload/Global/Service/Schnittstellen/AVM_Technical_Note_-_Session_ID_english_2021-05-03.pdf
+// Deprecated: use LoginCtx.
 func (client *Client) Login() error {
-	sessionInfo, err := client.obtainChallenge()
+	return client.LoginCtx(context.Background())
+}
+
+// LoginCtx tries to login into the box and obtain the session id, aborting early if ctx is
+// cancelled or its deadline is exceeded.
+// https://avm.de/fileadmin/user_upload/Global/Service/Schnittstellen/AVM_Technical_Note_-_Session_ID_english_2021-05-03.pdf
+func (client *Client) LoginCtx(ctx context.Context) error {
+	sessionInfo, err := client.obtainChallenge(ctx)
 	if err != nil {
 		return errors.Wrapf(err, "unable to obtain login challenge")
 	}
-	client.SessionInfo = sessionInfo
-	logger.Debug("FRITZ!Box challenge is", client.SessionInfo.Challenge)
-	err = client.solveChallenge()
+	logger.Debug("FRITZ!Box challenge is", sessionInfo.Challenge)
+	err = client.solveChallenge(ctx, sessionInfo)
 	if err != nil {
 		return errors.Wrapf(err, "unable to solve login challenge")
 	}
-	//client.SessionInfo = newSession
+	client.setSessionInfo(sessionInfo)
 	logger.Info("Login successful")
 	return nil
 }
 
-func (client *Client) obtainChallenge() (*SessionInfo, error) {
+func (client *Client) obtainChallenge(ctx context.Context) (*SessionInfo, error) {
 	url := client.Config.GetLoginURL()
 	getRemote := func() (*http.Response, error) {
-		return client.HTTPClient.Get(url)
+		return client.doResilient(ctx, func(ctx context.Context) (*http.Response, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			return client.HTTPClient.Do(req)
+		})
 	}
 	var sessionInfo SessionInfo
 
@@ -100,44 +156,80 @@ func (client *Client) obtainChallenge() (*SessionInfo, error) {
 	return &sessionInfo, err
 }
 
-func (client *Client) solveChallenge() error {
+// solveChallenge answers the login challenge held by sessionInfo and, on success, fills in its
+// SID. sessionInfo is a private, not-yet-published copy obtained from obtainChallenge, so this
+// may run concurrently with other Clients/goroutines without synchronization; only the final
+// swap into client.SessionInfo (done by the caller, LoginCtx) needs to be guarded.
+func (client *Client) solveChallenge(ctx context.Context, sessionInfo *SessionInfo) error {
+	usePBKDF2 := sessionInfo.IsPBKDF2
+	switch client.Config.Login.AuthMode {
+	case AuthModePBKDF2Only:
+		if !usePBKDF2 {
+			return ErrWeakAuthRejected
+		}
+	case AuthModeMD5Only:
+		usePBKDF2 = false
+	}
+
+	username, err := client.credentials().Username(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to obtain username: %w", err)
+	}
+	password, err := client.credentials().Password(ctx, sessionInfo.Challenge)
+	if err != nil {
+		return fmt.Errorf("failed to obtain password: %w", err)
+	}
+	defer zero(&password)
+
 	var challengeResponse string
-	var err error
-	if client.SessionInfo.IsPBKDF2 {
+	if usePBKDF2 {
 		logger.Debug("PBKDF2 supported")
-		challengeResponse, err = calculatePBKDF2Response(client.SessionInfo.Challenge, client.Config.Login.Password)
+		challengeResponse, err = calculatePBKDF2Response(sessionInfo.Challenge, password)
 		if err != nil {
 			return fmt.Errorf("failed to calculate PBKDF2 response: %w", err)
 		}
 	} else {
 		logger.Debug("Falling back to MD5")
-		challengeResponse = calculateMD5Response(client.SessionInfo.Challenge, client.Config.Login.Password)
+		challengeResponse = calculateMD5Response(sessionInfo.Challenge, password)
 	}
 
-	if client.SessionInfo.BlockTime > 0 {
-		logger.Info(fmt.Sprintf("Waiting for %d seconds...\n", client.SessionInfo.BlockTime))
-		time.Sleep(time.Duration(client.SessionInfo.BlockTime) * time.Second)
+	if sessionInfo.BlockTime > 0 {
+		logger.Info(fmt.Sprintf("Waiting for %d seconds...\n", sessionInfo.BlockTime))
+		timer := time.NewTimer(time.Duration(sessionInfo.BlockTime) * time.Second)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
 	}
 
-	sid, err := client.sendResponse(challengeResponse)
+	sid, err := client.sendResponse(ctx, username, challengeResponse)
 	if err != nil {
 		return fmt.Errorf("failed to login: %w", err)
 	}
 
-	client.SessionInfo.SID = sid
-	if client.SessionInfo.SID == "0000000000000000" || client.SessionInfo.SID == "" {
-		return fmt.Errorf("challenge not solved, got '%s' as session id, check login data", client.SessionInfo.SID)
+	sessionInfo.SID = sid
+	if sessionInfo.SID == "0000000000000000" || sessionInfo.SID == "" {
+		return fmt.Errorf("challenge not solved, got '%s' as session id, check login data", sessionInfo.SID)
 	}
 
 	return nil
 }
 
-func (client *Client) sendResponse(challengeResponse string) (string, error) {
+func (client *Client) sendResponse(ctx context.Context, username, challengeResponse string) (string, error) {
 	formData := url.Values{}
-	formData.Set("username", client.Config.Login.Username)
+	formData.Set("username", username)
 	formData.Set("response", challengeResponse)
 
-	resp, err := client.HTTPClient.PostForm(client.Config.GetLoginURL(), formData)
+	resp, err := client.doResilient(ctx, func(ctx context.Context) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, client.Config.GetLoginURL(), strings.NewReader(formData.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return client.HTTPClient.Do(req)
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to send response: %w", err)
 	}
@@ -225,12 +317,21 @@ func buildCertPool(cfg *config.Config) *x509.CertPool {
 }
 
 func (client *Client) query() fritzURLBuilder {
-	return newURLBuilder(client.Config).query("sid", client.SessionInfo.SID)
+	return newURLBuilder(client.Config).query("sid", client.sid())
 }
 
+// getf builds a lazily-evaluated GET request against url.
+// Deprecated: use getfCtx.
 func (client *Client) getf(url string) func() (*http.Response, error) {
+	return client.getfCtx(context.Background(), url)
+}
+
+// getfCtx builds a lazily-evaluated, context-aware GET request against url. The request is
+// retried on transient failures and, if the session has expired (HTTP 403), the client
+// re-logs in once and retries with a freshly-issued sid.
+func (client *Client) getfCtx(ctx context.Context, url string) func() (*http.Response, error) {
 	return func() (*http.Response, error) {
 		logger.Debug("GET", url)
-		return client.HTTPClient.Get(url)
+		return client.doAuthenticated(ctx, url)
 	}
 }
@@ -0,0 +1,175 @@
+package fritz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/bpicode/fritzctl/logger"
+)
+
+// retryPolicy controls how often and how long a failed request is retried before the caller
+// gives up. Zero values are replaced by sane defaults, see retryPolicyFrom.
+type retryPolicy struct {
+	MaxAttempts    int           // Upper bound on the number of tries, including the first one.
+	MaxElapsedTime time.Duration // Upper bound on the total time spent retrying.
+	BaseDelay      time.Duration // Upper bound for the jittered delay before the first retry.
+	MaxDelay       time.Duration // Upper bound for the backoff delay.
+}
+
+const (
+	defaultMaxAttempts    = 5
+	defaultMaxElapsedTime = 30 * time.Second
+	defaultBaseDelay      = 200 * time.Millisecond
+	defaultMaxDelay       = 5 * time.Second
+)
+
+// retryPolicyFrom derives a retryPolicy from the values configured in cfg.Retry, falling back
+// to the package defaults for anything left unset.
+func retryPolicyFrom(cfg *config.Config) retryPolicy {
+	policy := retryPolicy{
+		MaxAttempts:    defaultMaxAttempts,
+		MaxElapsedTime: defaultMaxElapsedTime,
+		BaseDelay:      defaultBaseDelay,
+		MaxDelay:       defaultMaxDelay,
+	}
+	if cfg == nil {
+		return policy
+	}
+	if cfg.Retry.MaxAttempts > 0 {
+		policy.MaxAttempts = cfg.Retry.MaxAttempts
+	}
+	if cfg.Retry.MaxElapsedSeconds > 0 {
+		policy.MaxElapsedTime = time.Duration(cfg.Retry.MaxElapsedSeconds) * time.Second
+	}
+	if cfg.Retry.BaseDelayMillis > 0 {
+		policy.BaseDelay = time.Duration(cfg.Retry.BaseDelayMillis) * time.Millisecond
+	}
+	if cfg.Retry.MaxDelayMillis > 0 {
+		policy.MaxDelay = time.Duration(cfg.Retry.MaxDelayMillis) * time.Millisecond
+	}
+	return policy
+}
+
+// doResilient executes do, retrying on HTTP 5xx responses and on net.Error timeouts with
+// exponential backoff and jitter. context.Canceled and context.DeadlineExceeded are treated as
+// terminal and returned to the caller unwrapped, since they express caller intent rather than a
+// transport failure.
+func (client *Client) doResilient(ctx context.Context, do func(ctx context.Context) (*http.Response, error)) (*http.Response, error) {
+	policy := retryPolicyFrom(client.Config)
+	start := time.Now()
+	var lastErr error
+	attempts := 0
+	for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := do(ctx)
+		attempts++
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil, context.Canceled
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				return nil, context.DeadlineExceeded
+			}
+			if !isRetryableNetErr(err) {
+				return nil, err
+			}
+			lastErr = err
+		} else {
+			lastErr = fmt.Errorf("server returned status %s", resp.Status)
+			resp.Body.Close()
+		}
+		if attempt == policy.MaxAttempts-1 || time.Since(start) >= policy.MaxElapsedTime {
+			break
+		}
+		delay := backoffDelay(policy, attempt)
+		logger.Debug(fmt.Sprintf("retrying request, attempt %d, after %s, reason: %v", attempt+1, delay, lastErr))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil, fmt.Errorf("giving up after %d attempts: %w", attempts, lastErr)
+}
+
+// doAuthenticated performs a GET request against an already-authenticated AHA endpoint. If the
+// FRITZ!Box reports an expired session (HTTP 403), the client logs in again once and retries the
+// request with the newly issued sid.
+func (client *Client) doAuthenticated(ctx context.Context, rawURL string) (*http.Response, error) {
+	resp, err := client.doResilient(ctx, func(ctx context.Context) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		return client.HTTPClient.Do(req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		return resp, nil
+	}
+	resp.Body.Close()
+	logger.Debug("session appears to be invalid (HTTP 403), re-logging in")
+	if err := client.LoginCtx(ctx); err != nil {
+		return nil, fmt.Errorf("session expired and relogin failed: %w", err)
+	}
+	atomic.AddInt64(&client.sessionReissues, 1)
+	retryURL := replaceSID(rawURL, client.sid())
+	return client.doResilient(ctx, func(ctx context.Context) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, retryURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		return client.HTTPClient.Do(req)
+	})
+}
+
+// replaceSID returns rawURL with its "sid" query parameter, if any, replaced by sid.
+func replaceSID(rawURL, sid string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	if _, ok := q["sid"]; !ok {
+		return rawURL
+	}
+	q.Set("sid", sid)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func isRetryableStatus(code int) bool {
+	return code >= 500 && code <= 599
+}
+
+func isRetryableNetErr(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// backoffDelay computes an exponential backoff delay for the given attempt (0-based), capped at
+// policy.MaxDelay and randomized with full jitter to avoid thundering-herd retries.
+func backoffDelay(policy retryPolicy, attempt int) time.Duration {
+	delay := policy.BaseDelay << attempt
+	if delay <= 0 || delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
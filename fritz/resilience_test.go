@@ -0,0 +1,145 @@
+package fritz
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bpicode/fritzctl/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastRetryConfig returns a config.Config whose retry policy is tuned for tests: a handful of
+// attempts with near-zero delays, so exercising the retry loop does not slow the suite down.
+func fastRetryConfig() *config.Config {
+	return &config.Config{Retry: config.Retry{MaxAttempts: 3, MaxElapsedSeconds: 5, BaseDelayMillis: 1, MaxDelayMillis: 2}}
+}
+
+// timeoutNetError is a minimal net.Error whose Timeout() always reports true, used to simulate a
+// transport timeout without depending on real network behavior.
+type timeoutNetError struct{}
+
+func (timeoutNetError) Error() string   { return "simulated timeout" }
+func (timeoutNetError) Timeout() bool   { return true }
+func (timeoutNetError) Temporary() bool { return true }
+
+// TestDoResilientRetriesOn5xxThenSucceeds tests that a transient 5xx is retried and the eventual
+// 200 response is returned to the caller.
+func TestDoResilientRetriesOn5xxThenSucceeds(t *testing.T) {
+	var attempts int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := NewClientFromConfig(fastRetryConfig())
+	resp, err := client.doResilient(context.Background(), func(ctx context.Context) (*http.Response, error) {
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		require.NoError(t, reqErr)
+		return client.HTTPClient.Do(req)
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 3, atomic.LoadInt64(&attempts))
+}
+
+// TestDoResilientGivesUpAfterMaxAttempts tests that a persistent 5xx exhausts the configured
+// number of attempts and is surfaced as an error rather than retried forever.
+func TestDoResilientGivesUpAfterMaxAttempts(t *testing.T) {
+	var attempts int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&attempts, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	client := NewClientFromConfig(fastRetryConfig())
+	_, err := client.doResilient(context.Background(), func(ctx context.Context) (*http.Response, error) {
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		require.NoError(t, reqErr)
+		return client.HTTPClient.Do(req)
+	})
+
+	require.Error(t, err)
+	assert.EqualValues(t, 3, atomic.LoadInt64(&attempts))
+}
+
+// TestDoResilientRetriesOnTimeoutNetError tests that a net.Error reporting Timeout() is retried
+// just like a 5xx response.
+func TestDoResilientRetriesOnTimeoutNetError(t *testing.T) {
+	client := NewClientFromConfig(fastRetryConfig())
+	var calls int
+	resp, err := client.doResilient(context.Background(), func(ctx context.Context) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return nil, timeoutNetError{}
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, calls)
+}
+
+// TestDoResilientTerminatesOnContextCanceled tests that an already-cancelled context is returned
+// unwrapped and never reaches do, since cancellation expresses caller intent rather than a
+// transport failure that should be retried.
+func TestDoResilientTerminatesOnContextCanceled(t *testing.T) {
+	client := NewClientFromConfig(fastRetryConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.doResilient(ctx, func(ctx context.Context) (*http.Response, error) {
+		t.Fatal("do must not be called once ctx is already canceled")
+		return nil, nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// TestDoAuthenticatedReLoginsOnceOn403 tests that a single expired-session response triggers
+// exactly one relogin and the request is retried with the freshly issued sid.
+func TestDoAuthenticatedReLoginsOnceOn403(t *testing.T) {
+	var ahaCalls int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login_sid.lua", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			fmt.Fprint(w, `<SessionInfo><SID>2222222222222222</SID></SessionInfo>`)
+			return
+		}
+		fmt.Fprint(w, `<SessionInfo><Challenge>abc</Challenge><SID>0000000000000000</SID><BlockTime>0</BlockTime></SessionInfo>`)
+	})
+	mux.HandleFunc("/webservices/homeautoswitch.lua", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&ahaCalls, 1) == 1 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		assert.Equal(t, "2222222222222222", r.URL.Query().Get("sid"))
+		fmt.Fprint(w, "ok")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewClientFromConfig(fastRetryConfig())
+	client.Config.SetURLsForTest(srv.URL)
+	client.SessionInfo = &SessionInfo{SID: "1111111111111111"}
+
+	resp, err := client.Do(context.Background(), "getswitchlist", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 1, client.SessionReissues())
+	assert.Equal(t, "2222222222222222", client.sid())
+}